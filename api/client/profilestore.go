@@ -0,0 +1,76 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+// ProfileStore defines the storage interface used to persist and retrieve
+// tsh profiles and the currently active profile name. The default
+// implementation, FSProfileStore, stores profiles as per-user YAML files
+// under the profile directory (e.g. ~/.tsh), but alternative
+// implementations (e.g. a shared database) can be used to centralize
+// profiles across machines.
+type ProfileStore interface {
+	// SaveToDir saves the profile to the given directory. If makeCurrent
+	// is true, the profile also becomes the current profile for dir.
+	SaveToDir(dir string, profile *Profile, makeCurrent bool) error
+	// ProfileFromDir reads the named profile from the given directory.
+	// If name is empty, the current profile is loaded instead.
+	ProfileFromDir(dir string, name string) (*Profile, error)
+	// ListProfileNames lists the names of all profiles in the given
+	// directory.
+	ListProfileNames(dir string) ([]string, error)
+	// SetCurrentProfileName sets the current profile name for dir.
+	SetCurrentProfileName(dir string, name string) error
+	// GetCurrentProfileName returns the current profile name for dir.
+	GetCurrentProfileName(dir string) (name string, err error)
+}
+
+// FSProfileStore is the filesystem-backed ProfileStore implementation.
+// It stores each profile as a YAML file named "<name>.yaml" in the
+// profile directory, and keeps track of the current profile in a
+// "current-profile" file in the same directory.
+type FSProfileStore struct {
+}
+
+// NewFSProfileStore returns a filesystem-backed ProfileStore.
+func NewFSProfileStore() *FSProfileStore {
+	return &FSProfileStore{}
+}
+
+// SaveToDir saves the profile to the given directory.
+func (s *FSProfileStore) SaveToDir(dir string, profile *Profile, makeCurrent bool) error {
+	return profile.SaveToDir(dir, makeCurrent)
+}
+
+// ProfileFromDir reads the named profile from the given directory.
+func (s *FSProfileStore) ProfileFromDir(dir string, name string) (*Profile, error) {
+	return ProfileFromDir(dir, name)
+}
+
+// ListProfileNames lists the names of all profiles in the given directory.
+func (s *FSProfileStore) ListProfileNames(dir string) ([]string, error) {
+	return ListProfileNames(dir)
+}
+
+// SetCurrentProfileName sets the current profile name for dir.
+func (s *FSProfileStore) SetCurrentProfileName(dir string, name string) error {
+	return SetCurrentProfileName(dir, name)
+}
+
+// GetCurrentProfileName returns the current profile name for dir.
+func (s *FSProfileStore) GetCurrentProfileName(dir string) (string, error) {
+	return GetCurrentProfileName(dir)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/client/db/profile"
+)
+
+// DetectClientFlavor inspects $PATH for known client binaries and returns a
+// sane default client flavor to use when the user hasn't specified one.
+//
+// "mariadb" is preferred over "mysql" when both are present, since the
+// MariaDB client doesn't understand MySQL-only option keys such as
+// "ssl-mode". If only a "mysql" binary is found, its --version output is
+// inspected to distinguish a Percona build from an Oracle MySQL build.
+func DetectClientFlavor() profile.ClientFlavor {
+	if _, err := exec.LookPath("mariadb"); err == nil {
+		return profile.ClientFlavorMariaDB
+	}
+	path, err := exec.LookPath("mysql")
+	if err != nil {
+		return profile.ClientFlavorMySQL
+	}
+	if isPerconaClient(path) {
+		return profile.ClientFlavorPercona
+	}
+	return profile.ClientFlavorMySQL
+}
+
+// isPerconaClient runs "mysql --version" and checks whether the output
+// identifies the binary as a Percona build.
+func isPerconaClient(path string) bool {
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), "Percona")
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package mysql
 
 import (
+	"fmt"
 	"os/user"
 	"path/filepath"
 	"strconv"
@@ -65,35 +66,92 @@ func LoadFromPath(path string) (*OptionFile, error) {
 	}, nil
 }
 
-// Upsert saves the provided connection profile in MySQL option file.
-func (o *OptionFile) Upsert(profile profile.ConnectProfile) error {
-	sectionName := o.section(profile.Name)
-	section := o.iniFile.Section(sectionName)
-	if section != nil {
-		o.iniFile.DeleteSection(sectionName)
+// Upsert saves the provided connection profile in the option file, using a
+// section layout and key names appropriate for profile.ClientFlavor.
+func (o *OptionFile) Upsert(cp profile.ConnectProfile) error {
+	var err error
+	switch cp.ClientFlavor {
+	case profile.ClientFlavorMariaDB:
+		err = o.upsertMariaDB(cp)
+	default:
+		// Percona's client is a drop-in replacement for the Oracle MySQL
+		// client and understands the same option keys.
+		err = o.upsertMySQL(cp)
 	}
-	section, err := o.iniFile.NewSection(sectionName)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	section.NewKey("host", profile.Host)
-	section.NewKey("port", strconv.Itoa(profile.Port))
-	if profile.User != "" {
-		section.NewKey("user", profile.User)
+	ini.PrettyFormat = false
+	return o.iniFile.SaveTo(o.path)
+}
+
+// upsertMySQL writes a single "[client_name]" section using Oracle MySQL
+// client option names (ssl-mode, etc), also used for the Percona client.
+func (o *OptionFile) upsertMySQL(cp profile.ConnectProfile) error {
+	section, err := o.newSection(o.section(cp.Name))
+	if err != nil {
+		return trace.Wrap(err)
 	}
-	if profile.Database != "" {
-		section.NewKey("database", profile.Database)
+	o.writeCommonKeys(section, cp)
+	sslMode, err := sslMode(cp)
+	if err != nil {
+		return trace.Wrap(err)
 	}
-	if profile.Insecure {
-		section.NewKey("ssl-mode", MySQLSSLModeVerifyCA)
-	} else {
-		section.NewKey("ssl-mode", MySQLSSLModeVerifyIdentity)
+	section.NewKey("ssl-mode", sslMode)
+	section.NewKey("ssl-ca", cp.CACertPath)
+	section.NewKey("ssl-cert", cp.CertPath)
+	section.NewKey("ssl-key", cp.KeyPath)
+	if cp.TLSVersion != "" {
+		section.NewKey("tls-version", cp.TLSVersion)
+	}
+	if cp.SSLCipher != "" {
+		section.NewKey("ssl-cipher", cp.SSLCipher)
+	}
+	return nil
+}
+
+// upsertMariaDB writes both a "[client_name]" and a "[mariadb-client_name]"
+// section using MariaDB client option names, which don't understand
+// "ssl-mode" and use "ssl"/"ssl-verify-server-cert" instead.
+func (o *OptionFile) upsertMariaDB(cp profile.ConnectProfile) error {
+	for _, sectionName := range []string{o.section(cp.Name), o.mariaDBSection(cp.Name)} {
+		section, err := o.newSection(sectionName)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		o.writeCommonKeys(section, cp)
+		section.NewKey("ssl", "true")
+		section.NewKey("ssl-verify-server-cert", strconv.FormatBool(!cp.Insecure))
+		section.NewKey("ssl-ca", cp.CACertPath)
+		section.NewKey("ssl-cert", cp.CertPath)
+		section.NewKey("ssl-key", cp.KeyPath)
+		if cp.SSLCipher != "" {
+			section.NewKey("ssl-cipher", cp.SSLCipher)
+		}
+	}
+	return nil
+}
+
+// newSection creates a fresh section with the given name, deleting any
+// existing section of the same name first.
+func (o *OptionFile) newSection(name string) (*ini.Section, error) {
+	if o.iniFile.Section(name) != nil {
+		o.iniFile.DeleteSection(name)
+	}
+	return o.iniFile.NewSection(name)
+}
+
+// writeCommonKeys writes the connection parameters shared by all client
+// flavors (host, port, user, database).
+func (o *OptionFile) writeCommonKeys(section *ini.Section, cp profile.ConnectProfile) {
+	section.NewKey("host", cp.Host)
+	section.NewKey("port", strconv.Itoa(cp.Port))
+	if cp.User != "" {
+		section.NewKey("user", cp.User)
+	}
+	if cp.Database != "" {
+		section.NewKey("database", cp.Database)
 	}
-	section.NewKey("ssl-ca", profile.CACertPath)
-	section.NewKey("ssl-cert", profile.CertPath)
-	section.NewKey("ssl-key", profile.KeyPath)
-	ini.PrettyFormat = false
-	return o.iniFile.SaveTo(o.path)
 }
 
 // Env returns the specified connection profile as environment variables.
@@ -118,6 +176,81 @@ func (o *OptionFile) Env(name string) (map[string]string, error) {
 	}, nil
 }
 
+// EnvAll returns the specified connection profile as a full set of
+// MYSQL_* environment variables, for environments such as containers/CI
+// and Go programs using go-sql-driver/mysql that can't rely on
+// "--defaults-group-suffix" the way the mysql CLI can. Unlike Env, the
+// returned variables are sufficient on their own to connect, including a
+// synthesized MYSQL_DSN in go-sql-driver DSN format.
+func (o *OptionFile) EnvAll(name string) (map[string]string, error) {
+	section, err := o.iniFile.GetSection(o.section(name))
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return nil, trace.NotFound("connection profile %q not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	host := section.Key("host").String()
+	port := section.Key("port").String()
+	user := section.Key("user").String()
+	database := section.Key("database").String()
+
+	env := map[string]string{
+		"MYSQL_HOST":     host,
+		"MYSQL_TCP_PORT": port,
+	}
+	if user != "" {
+		env["MYSQL_USER"] = user
+	}
+	// Note: tsh connection profiles authenticate with client certificates,
+	// not passwords, so there's no "password" key to read here and
+	// consequently no MYSQL_PWD is set.
+	if ca := section.Key("ssl-ca").String(); ca != "" {
+		env["MYSQL_SSL_CA"] = ca
+	}
+	if cert := section.Key("ssl-cert").String(); cert != "" {
+		env["MYSQL_SSL_CERT"] = cert
+	}
+	if key := section.Key("ssl-key").String(); key != "" {
+		env["MYSQL_SSL_KEY"] = key
+	}
+	env["MYSQL_DSN"] = dsn(user, host, port, database)
+	return env, nil
+}
+
+// dsn builds a go-sql-driver/mysql DSN of the form
+// "user@tcp(host:port)/db?tls=custom" for the resolved connection
+// parameters. TLS is always requested via "tls=custom" since tsh always
+// configures client certificates; it's up to the caller to register that
+// TLS config name with the driver (e.g. via mysql.RegisterTLSConfig)
+// using the MYSQL_SSL_* paths returned alongside it.
+func dsn(user, host, port, database string) string {
+	userPart := ""
+	if user != "" {
+		userPart = user + "@"
+	}
+	return fmt.Sprintf("%vtcp(%v:%v)/%v?tls=custom", userPart, host, port, database)
+}
+
+// sslMode returns the ssl-mode value to use for the given profile,
+// validating it if the profile sets one explicitly and falling back to a
+// mode based on profile.Insecure otherwise.
+func sslMode(profile profile.ConnectProfile) (string, error) {
+	if profile.SSLMode == "" {
+		if profile.Insecure {
+			return MySQLSSLModeVerifyCA, nil
+		}
+		return MySQLSSLModeVerifyIdentity, nil
+	}
+	switch profile.SSLMode {
+	case MySQLSSLModeDisabled, MySQLSSLModePreferred, MySQLSSLModeRequired,
+		MySQLSSLModeVerifyCA, MySQLSSLModeVerifyIdentity:
+		return profile.SSLMode, nil
+	}
+	return "", trace.BadParameter("invalid MySQL ssl-mode %q", profile.SSLMode)
+}
+
 // Delete removes the specified connection profile.
 func (o *OptionFile) Delete(name string) error {
 	o.iniFile.DeleteSection(o.section(name))
@@ -131,11 +264,34 @@ func (o *OptionFile) section(name string) string {
 	return "client" + o.suffix(name)
 }
 
+// mariaDBSection returns the MariaDB-specific section name, which the
+// MariaDB client reads in addition to (and with priority over) "client".
+func (o *OptionFile) mariaDBSection(name string) string {
+	return "mariadb-client" + o.suffix(name)
+}
+
 func (o *OptionFile) suffix(name string) string {
 	return "_" + name
 }
 
 const (
+	// MySQLSSLModeDisabled is MySQL SSL mode that disables encryption.
+	//
+	// See MySQL SSL mode docs for more info:
+	// https://dev.mysql.com/doc/refman/8.0/en/connection-options.html#option_general_ssl-mode
+	MySQLSSLModeDisabled = "DISABLED"
+	// MySQLSSLModePreferred is MySQL SSL mode that establishes an encrypted
+	// connection if the server supports it, falling back to unencrypted.
+	//
+	// See MySQL SSL mode docs for more info:
+	// https://dev.mysql.com/doc/refman/8.0/en/connection-options.html#option_general_ssl-mode
+	MySQLSSLModePreferred = "PREFERRED"
+	// MySQLSSLModeRequired is MySQL SSL mode that requires encryption but
+	// does not verify the server certificate.
+	//
+	// See MySQL SSL mode docs for more info:
+	// https://dev.mysql.com/doc/refman/8.0/en/connection-options.html#option_general_ssl-mode
+	MySQLSSLModeRequired = "REQUIRED"
 	// MySQLSSLModeVerifyCA is MySQL SSL mode that verifies server CA.
 	//
 	// See MySQL SSL mode docs for more info:
@@ -164,3 +320,32 @@ Or configure environment variables and use regular CLI flags:
   $ mysql
 
 `))
+
+// mariaDBMessage is printed after a MariaDB option file section has been
+// updated.
+var mariaDBMessage = template.Must(template.New("").Parse(`
+Connection information for MySQL database "{{.Name}}" has been saved.
+
+You can now connect to the database using the following command:
+
+  $ mariadb --defaults-group-suffix=_{{.Name}}
+
+Or configure environment variables and use regular CLI flags:
+
+  $ eval $(tsh db env)
+  $ mariadb
+
+`))
+
+// MessageFor returns the connect-instructions template appropriate for the
+// given client flavor. Percona Server uses the same mysql client and
+// connection instructions as upstream MySQL, so it falls through to
+// Message along with the default case.
+func MessageFor(flavor profile.ClientFlavor) *template.Template {
+	switch flavor {
+	case profile.ClientFlavorMariaDB:
+		return mariaDBMessage
+	default:
+		return Message
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/client/db/profile"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionFileSSLMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  profile.ConnectProfile
+		wantMode string
+		wantErr  bool
+	}{
+		{
+			name:     "defaults to verify identity",
+			profile:  profile.ConnectProfile{Name: "test"},
+			wantMode: MySQLSSLModeVerifyIdentity,
+		},
+		{
+			name:     "insecure defaults to verify CA",
+			profile:  profile.ConnectProfile{Name: "test", Insecure: true},
+			wantMode: MySQLSSLModeVerifyCA,
+		},
+		{
+			name:     "explicit disabled",
+			profile:  profile.ConnectProfile{Name: "test", SSLMode: MySQLSSLModeDisabled},
+			wantMode: MySQLSSLModeDisabled,
+		},
+		{
+			name:     "explicit preferred",
+			profile:  profile.ConnectProfile{Name: "test", SSLMode: MySQLSSLModePreferred},
+			wantMode: MySQLSSLModePreferred,
+		},
+		{
+			name:     "explicit required",
+			profile:  profile.ConnectProfile{Name: "test", SSLMode: MySQLSSLModeRequired},
+			wantMode: MySQLSSLModeRequired,
+		},
+		{
+			name:     "explicit verify CA",
+			profile:  profile.ConnectProfile{Name: "test", SSLMode: MySQLSSLModeVerifyCA},
+			wantMode: MySQLSSLModeVerifyCA,
+		},
+		{
+			name:     "explicit verify identity",
+			profile:  profile.ConnectProfile{Name: "test", SSLMode: MySQLSSLModeVerifyIdentity},
+			wantMode: MySQLSSLModeVerifyIdentity,
+		},
+		{
+			name:    "invalid mode",
+			profile: profile.ConnectProfile{Name: "test", SSLMode: "BOGUS"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, err := sslMode(test.profile)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.wantMode, mode)
+		})
+	}
+}
+
+func TestOptionFileUpsertWritesSSLOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".my.cnf")
+	optionFile, err := LoadFromPath(path)
+	require.NoError(t, err)
+
+	err = optionFile.Upsert(profile.ConnectProfile{
+		Name:       "test",
+		Host:       "localhost",
+		Port:       3306,
+		SSLMode:    MySQLSSLModeRequired,
+		TLSVersion: "TLSv1.2",
+		SSLCipher:  "DHE-RSA-AES256-SHA",
+	})
+	require.NoError(t, err)
+
+	optionFile, err = LoadFromPath(path)
+	require.NoError(t, err)
+	section, err := optionFile.iniFile.GetSection("client_test")
+	require.NoError(t, err)
+	require.Equal(t, MySQLSSLModeRequired, section.Key("ssl-mode").String())
+	require.Equal(t, "TLSv1.2", section.Key("tls-version").String())
+	require.Equal(t, "DHE-RSA-AES256-SHA", section.Key("ssl-cipher").String())
+}
+
+func TestOptionFileUpsertMariaDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".my.cnf")
+	optionFile, err := LoadFromPath(path)
+	require.NoError(t, err)
+
+	err = optionFile.Upsert(profile.ConnectProfile{
+		Name:         "test",
+		Host:         "localhost",
+		Port:         3306,
+		ClientFlavor: profile.ClientFlavorMariaDB,
+	})
+	require.NoError(t, err)
+
+	optionFile, err = LoadFromPath(path)
+	require.NoError(t, err)
+
+	for _, sectionName := range []string{"client_test", "mariadb-client_test"} {
+		section, err := optionFile.iniFile.GetSection(sectionName)
+		require.NoError(t, err)
+		require.Equal(t, "localhost", section.Key("host").String())
+		require.Equal(t, "true", section.Key("ssl").String())
+		require.Equal(t, "true", section.Key("ssl-verify-server-cert").String())
+		require.False(t, section.HasKey("ssl-mode"))
+	}
+}
+
+func TestOptionFileEnvAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".my.cnf")
+	optionFile, err := LoadFromPath(path)
+	require.NoError(t, err)
+
+	err = optionFile.Upsert(profile.ConnectProfile{
+		Name:       "test",
+		Host:       "localhost",
+		Port:       3306,
+		User:       "alice",
+		Database:   "app",
+		CACertPath: "/certs/ca.pem",
+		CertPath:   "/certs/cert.pem",
+		KeyPath:    "/certs/key.pem",
+	})
+	require.NoError(t, err)
+
+	env, err := optionFile.EnvAll("test")
+	require.NoError(t, err)
+	require.Equal(t, "localhost", env["MYSQL_HOST"])
+	require.Equal(t, "3306", env["MYSQL_TCP_PORT"])
+	require.Equal(t, "alice", env["MYSQL_USER"])
+	require.Equal(t, "/certs/ca.pem", env["MYSQL_SSL_CA"])
+	require.Equal(t, "/certs/cert.pem", env["MYSQL_SSL_CERT"])
+	require.Equal(t, "/certs/key.pem", env["MYSQL_SSL_KEY"])
+	require.Equal(t, "alice@tcp(localhost:3306)/app?tls=custom", env["MYSQL_DSN"])
+	_, hasPwd := env["MYSQL_PWD"]
+	require.False(t, hasPwd, "tsh connection profiles authenticate with client certs, not passwords")
+
+	_, err = optionFile.EnvAll("missing")
+	require.True(t, trace.IsNotFound(err))
+}
+
+func TestMessageForPercona(t *testing.T) {
+	// Percona Server uses the same mysql client as upstream MySQL, so it
+	// should get the same connect instructions as the default case.
+	require.Same(t, Message, MessageFor(profile.ClientFlavorPercona))
+	require.Same(t, Message, MessageFor(""))
+}
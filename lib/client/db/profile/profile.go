@@ -0,0 +1,69 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profile defines connection profile used by database client
+// configuration writers (e.g. lib/client/db/mysql) to render the files
+// and environment variables a particular database CLI client expects.
+package profile
+
+// ConnectProfile groups database connection parameters used to generate
+// client configuration (e.g. a MySQL option file section).
+type ConnectProfile struct {
+	// Name is the name of the connection profile.
+	Name string
+	// Host is the database server host.
+	Host string
+	// Port is the database server port.
+	Port int
+	// User is an optional database user name.
+	User string
+	// Database is an optional database name.
+	Database string
+	// Insecure is whether to skip host verification.
+	Insecure bool
+	// CACertPath is the path to the CA certificate used to verify the
+	// database server.
+	CACertPath string
+	// CertPath is the path to the client certificate file.
+	CertPath string
+	// KeyPath is the path to the client key file.
+	KeyPath string
+	// SSLMode is the MySQL SSL mode to connect with, e.g. VERIFY_IDENTITY.
+	// When empty, a mode is chosen based on Insecure.
+	SSLMode string
+	// TLSVersion is an optional minimum TLS version to require, e.g. TLSv1.2.
+	TLSVersion string
+	// SSLCipher is an optional list of permitted ciphers to use for the
+	// connection, e.g. "DHE-RSA-AES256-SHA".
+	SSLCipher string
+	// ClientFlavor identifies the MySQL-compatible client binary that will
+	// read the generated configuration, e.g. MySQL, MariaDB or Percona.
+	// When empty, MySQL semantics are assumed.
+	ClientFlavor ClientFlavor
+}
+
+// ClientFlavor identifies a specific MySQL-compatible client distribution,
+// since option file section layout and SSL key names diverge between them.
+type ClientFlavor string
+
+const (
+	// ClientFlavorMySQL is the official Oracle MySQL client.
+	ClientFlavorMySQL ClientFlavor = "mysql"
+	// ClientFlavorMariaDB is the MariaDB client.
+	ClientFlavorMariaDB ClientFlavor = "mariadb"
+	// ClientFlavorPercona is the Percona Server client.
+	ClientFlavorPercona ClientFlavor = "percona"
+)
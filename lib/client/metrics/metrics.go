@@ -0,0 +1,80 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides an opt-in Prometheus metrics and health-check
+// HTTP endpoint for long-running tsh invocations (e.g. "tsh proxy db"),
+// along with instrumented wrappers around tsh profile and database
+// connection-profile operations.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// resultSuccess is the "result" label value for a successful operation.
+	resultSuccess = "success"
+	// resultError is the "result" label value for a failed operation.
+	resultError = "error"
+)
+
+var (
+	// ProfileOpsTotal counts tsh profile and DB-config operations by type
+	// and outcome.
+	ProfileOpsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "tsh",
+		Name:      "profile_ops_total",
+		Help:      "Total number of tsh profile and DB-config operations.",
+	}, []string{"op", "result"})
+
+	// ProfileOpLatency tracks the latency of tsh profile and DB-config
+	// operations by type.
+	ProfileOpLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "teleport",
+		Subsystem: "tsh",
+		Name:      "profile_op_latency_seconds",
+		Help:      "Latency of tsh profile and DB-config operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"op"})
+
+	// ProfilesLoaded is a gauge of the number of profiles currently loaded
+	// in the active profile directory.
+	ProfilesLoaded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "teleport",
+		Subsystem: "tsh",
+		Name:      "profiles_loaded",
+		Help:      "Number of tsh profiles currently loaded.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(ProfileOpsTotal, ProfileOpLatency, ProfilesLoaded)
+}
+
+// observe records the outcome and latency of a single named operation.
+// Latency is the time elapsed since start, which callers capture before
+// running the operation.
+func observe(op string, err error, start time.Time) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	ProfileOpsTotal.WithLabelValues(op, result).Inc()
+	ProfileOpLatency.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/client/db/mysql"
+	"github.com/gravitational/teleport/lib/client/db/profile"
+
+	"github.com/gravitational/trace"
+)
+
+// InstrumentedOptionFile wraps a *mysql.OptionFile and records operation
+// counters and latency histograms for each call.
+type InstrumentedOptionFile struct {
+	file *mysql.OptionFile
+}
+
+// NewInstrumentedOptionFile wraps file with Prometheus instrumentation.
+func NewInstrumentedOptionFile(file *mysql.OptionFile) *InstrumentedOptionFile {
+	return &InstrumentedOptionFile{file: file}
+}
+
+// Upsert saves the provided connection profile in the MySQL option file.
+func (o *InstrumentedOptionFile) Upsert(cp profile.ConnectProfile) error {
+	start := time.Now()
+	err := o.file.Upsert(cp)
+	observe("mysql_option_file_upsert", err, start)
+	return trace.Wrap(err)
+}
+
+// Env returns the specified connection profile as environment variables.
+func (o *InstrumentedOptionFile) Env(name string) (map[string]string, error) {
+	start := time.Now()
+	env, err := o.file.Env(name)
+	observe("mysql_option_file_env", err, start)
+	return env, trace.Wrap(err)
+}
+
+// Delete removes the specified connection profile.
+func (o *InstrumentedOptionFile) Delete(name string) error {
+	start := time.Now()
+	err := o.file.Delete(name)
+	observe("mysql_option_file_delete", err, start)
+	return trace.Wrap(err)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/client/db/mysql"
+	"github.com/gravitational/teleport/lib/client/db/profile"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInstrumentedOptionFileRecordsMetrics verifies that exercising an
+// InstrumentedOptionFile updates ProfileOpsTotal.
+func TestInstrumentedOptionFileRecordsMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".my.cnf")
+	file, err := mysql.LoadFromPath(path)
+	require.NoError(t, err)
+	optionFile := NewInstrumentedOptionFile(file)
+
+	before := testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("mysql_option_file_upsert", resultSuccess))
+	require.NoError(t, optionFile.Upsert(profile.ConnectProfile{Name: "test", Host: "localhost", Port: 3306}))
+	require.Equal(t, before+1, testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("mysql_option_file_upsert", resultSuccess)))
+
+	env, err := optionFile.Env("test")
+	require.NoError(t, err)
+	require.NotEmpty(t, env["MYSQL_GROUP_SUFFIX"])
+	require.Equal(t, float64(1), testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("mysql_option_file_env", resultSuccess)))
+
+	require.NoError(t, optionFile.Delete("test"))
+	require.Equal(t, float64(1), testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("mysql_option_file_delete", resultSuccess)))
+
+	_, err = optionFile.Env("missing")
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("mysql_option_file_env", resultError)))
+}
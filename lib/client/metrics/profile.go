@@ -0,0 +1,80 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/api/client"
+
+	"github.com/gravitational/trace"
+)
+
+// InstrumentedProfileStore wraps a client.ProfileStore and records
+// operation counters and latency histograms for each call.
+type InstrumentedProfileStore struct {
+	store client.ProfileStore
+}
+
+// NewInstrumentedProfileStore wraps store with Prometheus instrumentation.
+func NewInstrumentedProfileStore(store client.ProfileStore) *InstrumentedProfileStore {
+	return &InstrumentedProfileStore{store: store}
+}
+
+// SaveToDir saves the profile to the given directory.
+func (s *InstrumentedProfileStore) SaveToDir(dir string, profile *client.Profile, makeCurrent bool) error {
+	start := time.Now()
+	err := s.store.SaveToDir(dir, profile, makeCurrent)
+	observe("profile_save", err, start)
+	return trace.Wrap(err)
+}
+
+// ProfileFromDir reads the named profile from the given directory.
+func (s *InstrumentedProfileStore) ProfileFromDir(dir string, name string) (*client.Profile, error) {
+	start := time.Now()
+	profile, err := s.store.ProfileFromDir(dir, name)
+	observe("profile_load", err, start)
+	return profile, trace.Wrap(err)
+}
+
+// ListProfileNames lists the names of all profiles in the given directory
+// and updates the loaded-profiles gauge.
+func (s *InstrumentedProfileStore) ListProfileNames(dir string) ([]string, error) {
+	start := time.Now()
+	names, err := s.store.ListProfileNames(dir)
+	observe("profile_list", err, start)
+	if err == nil {
+		ProfilesLoaded.Set(float64(len(names)))
+	}
+	return names, trace.Wrap(err)
+}
+
+// SetCurrentProfileName sets the current profile name for dir.
+func (s *InstrumentedProfileStore) SetCurrentProfileName(dir string, name string) error {
+	start := time.Now()
+	err := s.store.SetCurrentProfileName(dir, name)
+	observe("profile_set_current", err, start)
+	return trace.Wrap(err)
+}
+
+// GetCurrentProfileName returns the current profile name for dir.
+func (s *InstrumentedProfileStore) GetCurrentProfileName(dir string) (string, error) {
+	start := time.Now()
+	name, err := s.store.GetCurrentProfileName(dir)
+	observe("profile_get_current", err, start)
+	return name, trace.Wrap(err)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/api/client"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInstrumentedProfileStoreRecordsMetrics verifies that exercising an
+// InstrumentedProfileStore updates ProfileOpsTotal and ProfilesLoaded.
+func TestInstrumentedProfileStoreRecordsMetrics(t *testing.T) {
+	dir := t.TempDir()
+	store := NewInstrumentedProfileStore(client.NewFSProfileStore())
+
+	before := testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("profile_save", resultSuccess))
+
+	profile := &client.Profile{WebProxyAddr: "proxy.example.com:3080", Username: "alice"}
+	require.NoError(t, store.SaveToDir(dir, profile, true))
+	require.Equal(t, before+1, testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("profile_save", resultSuccess)))
+
+	loaded, err := store.ProfileFromDir(dir, "proxy.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "alice", loaded.Username)
+	require.Equal(t, float64(1), testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("profile_load", resultSuccess)))
+
+	names, err := store.ListProfileNames(dir)
+	require.NoError(t, err)
+	require.Contains(t, names, "proxy.example.com")
+	require.Equal(t, float64(len(names)), testutil.ToFloat64(ProfilesLoaded))
+
+	require.NoError(t, store.SetCurrentProfileName(dir, "proxy.example.com"))
+	current, err := store.GetCurrentProfileName(dir)
+	require.NoError(t, err)
+	require.Equal(t, "proxy.example.com", current)
+
+	_, err = store.ProfileFromDir(dir, "missing")
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(ProfileOpsTotal.WithLabelValues("profile_load", resultError)))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// authProxyFetchTimeout bounds how long a "/metrics" scrape waits on the
+// upstream auth server before giving up, so a hung or unreachable auth
+// server can't block local scrapes indefinitely.
+const authProxyFetchTimeout = 5 * time.Second
+
+// Config is the configuration for a metrics Server.
+type Config struct {
+	// ListenAddr is the address the metrics server listens on, e.g.
+	// "127.0.0.1:9090". Required.
+	ListenAddr string
+	// AuthProxyMetricsAddr is an optional address of an upstream auth
+	// server's "/metrics" endpoint. When set, its output is appended to
+	// this server's own "/metrics" response so ops teams can scrape a
+	// single endpoint per user session instead of one per component.
+	AuthProxyMetricsAddr string
+}
+
+// CheckAndSetDefaults validates the config.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.ListenAddr == "" {
+		return trace.BadParameter("missing metrics server listen address")
+	}
+	return nil
+}
+
+// Server is an opt-in HTTP server exposing Prometheus metrics and a health
+// check for long-running tsh invocations, e.g. "tsh proxy db --metrics-addr".
+type Server struct {
+	cfg      Config
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer returns a metrics Server configured per cfg, with its listening
+// socket already bound so callers can start serving on a goroutine without
+// racing a caller that immediately depends on the server being reachable.
+func NewServer(cfg Config) (*Server, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	s := &Server{cfg: cfg, listener: listener}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metricsHandler())
+	mux.HandleFunc("/healthz", s.healthzHandler)
+	s.server = &http.Server{
+		Handler: mux,
+	}
+	return s, nil
+}
+
+// ListenAndServe starts serving metrics and health check requests on the
+// listener NewServer already bound. It blocks until the server is stopped
+// via Shutdown or fails to start.
+func (s *Server) ListenAndServe() error {
+	err := s.server.Serve(s.listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return trace.Wrap(err)
+}
+
+// Shutdown gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return trace.Wrap(s.server.Shutdown(ctx))
+}
+
+// metricsHandler serves the local registry in Prometheus text format,
+// optionally followed by the upstream auth server's own "/metrics" output
+// when AuthProxyMetricsAddr is configured (an "exporter of exporters").
+func (s *Server) metricsHandler() http.Handler {
+	local := promhttp.Handler()
+	if s.cfg.AuthProxyMetricsAddr == "" {
+		return local
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local.ServeHTTP(w, r)
+
+		ctx, cancel := context.WithTimeout(r.Context(), authProxyFetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.cfg.AuthProxyMetricsAddr+"/metrics", nil)
+		if err != nil {
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			// The local metrics have already been written; don't fail the
+			// whole scrape just because the upstream auth server is down
+			// or slow to respond.
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			io.Copy(w, resp.Body)
+		}
+	})
+}
+
+// healthzHandler reports this process as healthy as long as it's able to
+// respond at all.
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
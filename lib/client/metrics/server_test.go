@@ -0,0 +1,59 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetricsHandlerBoundsUpstreamFetch verifies that a scrape of the local
+// "/metrics" endpoint completes promptly even when the configured auth
+// proxy metrics endpoint never responds.
+func TestMetricsHandlerBoundsUpstreamFetch(t *testing.T) {
+	hang := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	// Release the hung handler before Close waits for its connection to
+	// finish; Close must run after close(hang), so defers are registered in
+	// reverse of their intended run order.
+	defer upstream.Close()
+	defer close(hang)
+
+	s := &Server{cfg: Config{ListenAddr: "127.0.0.1:0", AuthProxyMetricsAddr: upstream.Listener.Addr().String()}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+	done := make(chan struct{})
+	go func() {
+		s.metricsHandler().ServeHTTP(rec, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(authProxyFetchTimeout + 5*time.Second):
+		t.Fatal("metrics handler did not return within the upstream fetch timeout")
+	}
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mysql implements a MySQL-backed client.ProfileStore, allowing tsh
+// profiles to be shared across machines (e.g. ephemeral CI runners) instead
+// of living only in per-user YAML files under ~/.tsh.
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/gravitational/teleport/api/client"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gravitational/trace"
+	"gopkg.in/yaml.v2"
+)
+
+// schema creates the tables used to store profiles if they don't already
+// exist. It's applied every time a Store is created so that upgrades don't
+// require a separate migration step.
+const schema = `
+CREATE TABLE IF NOT EXISTS profiles (
+	dir VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	yaml_blob MEDIUMBLOB NOT NULL,
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (dir, name)
+);
+
+CREATE TABLE IF NOT EXISTS current_profile (
+	dir VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	PRIMARY KEY (dir)
+);
+`
+
+// Config is the MySQL connection configuration for a profile Store.
+type Config struct {
+	// Host is the MySQL server host.
+	Host string
+	// Port is the MySQL server port.
+	Port int
+	// User is the MySQL user.
+	User string
+	// Password is the MySQL user's password.
+	Password string
+	// Database is the name of the database that holds the profile tables.
+	Database string
+	// TLSCA is an optional path to a CA certificate used to verify the
+	// MySQL server. When empty, the connection is not encrypted.
+	TLSCA string
+}
+
+// CheckAndSetDefaults validates the config and sets default values.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Host == "" {
+		return trace.BadParameter("missing profile store MySQL host")
+	}
+	if c.Port == 0 {
+		c.Port = 3306
+	}
+	if c.User == "" {
+		return trace.BadParameter("missing profile store MySQL user")
+	}
+	if c.Database == "" {
+		return trace.BadParameter("missing profile store MySQL database")
+	}
+	return nil
+}
+
+// dsn builds a go-sql-driver/mysql DSN from the config.
+func (c *Config) dsn() (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = fmt.Sprintf("%v:%v", c.Host, c.Port)
+	cfg.User = c.User
+	cfg.Passwd = c.Password
+	cfg.DBName = c.Database
+	cfg.ParseTime = true
+	// schema is applied as a single multi-statement query on startup, so
+	// the driver needs to be told to allow that.
+	cfg.MultiStatements = true
+	if c.TLSCA != "" {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if err := mysql.RegisterTLSConfig("profile-store", tlsConfig); err != nil {
+			return "", trace.Wrap(err)
+		}
+		cfg.TLSConfig = "profile-store"
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// tlsConfig builds a *tls.Config that trusts the configured CA certificate.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	caCert, err := ioutil.ReadFile(c.TLSCA)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, trace.BadParameter("invalid CA cert PEM in %v", c.TLSCA)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// Store is a MySQL-backed implementation of client.ProfileStore.
+//
+// Profiles are stored in the "profiles" table keyed by (dir, name), with
+// the profile itself serialized as YAML. The currently active profile for
+// a directory is tracked in the "current_profile" table, mirroring the
+// "current-profile" file used by client.FSProfileStore.
+type Store struct {
+	cfg Config
+	db  *sql.DB
+}
+
+// New connects to the configured MySQL database and returns a Store,
+// creating the backing tables if they don't already exist.
+func New(cfg Config) (*Store, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	dsn, err := cfg.dsn()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Store{cfg: cfg, db: db}, nil
+}
+
+// SaveToDir saves the profile to the given directory.
+func (s *Store) SaveToDir(dir string, profile *client.Profile, makeCurrent bool) error {
+	blob, err := yaml.Marshal(profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO profiles (dir, name, yaml_blob, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE yaml_blob = VALUES(yaml_blob), updated_at = VALUES(updated_at)`,
+		dir, profile.Name(), blob, time.Now().UTC())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if makeCurrent {
+		return trace.Wrap(s.SetCurrentProfileName(dir, profile.Name()))
+	}
+	return nil
+}
+
+// ProfileFromDir reads the named profile from the given directory. If name
+// is empty, the current profile is loaded instead.
+func (s *Store) ProfileFromDir(dir string, name string) (*client.Profile, error) {
+	var err error
+	if name == "" {
+		name, err = s.GetCurrentProfileName(dir)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	var blob []byte
+	err = s.db.QueryRow(`SELECT yaml_blob FROM profiles WHERE dir = ? AND name = ?`, dir, name).Scan(&blob)
+	if err == sql.ErrNoRows {
+		return nil, trace.NotFound("profile %q not found", name)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var profile *client.Profile
+	if err := yaml.Unmarshal(blob, &profile); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	profile.Dir = dir
+	return profile, nil
+}
+
+// ListProfileNames lists the names of all profiles in the given directory.
+func (s *Store) ListProfileNames(dir string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM profiles WHERE dir = ?`, dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		names = append(names, name)
+	}
+	return names, trace.Wrap(rows.Err())
+}
+
+// SetCurrentProfileName sets the current profile name for dir.
+func (s *Store) SetCurrentProfileName(dir string, name string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO current_profile (dir, name) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE name = VALUES(name)`, dir, name)
+	return trace.Wrap(err)
+}
+
+// GetCurrentProfileName returns the current profile name for dir.
+func (s *Store) GetCurrentProfileName(dir string) (string, error) {
+	var name string
+	err := s.db.QueryRow(`SELECT name FROM current_profile WHERE dir = ?`, dir).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", trace.NotFound("current-profile is not set")
+	}
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return name, nil
+}
+
+// Close closes the underlying database connection.
+func (s *Store) Close() error {
+	return trace.Wrap(s.db.Close())
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDSNAllowsMultiStatements(t *testing.T) {
+	cfg := Config{
+		Host:     "localhost",
+		User:     "root",
+		Database: "tsh_profiles",
+	}
+	require.NoError(t, cfg.CheckAndSetDefaults())
+
+	dsn, err := cfg.dsn()
+	require.NoError(t, err)
+
+	// The schema migration in New() runs as a single multi-statement
+	// query, which the driver refuses to execute unless this is set.
+	parsed, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.True(t, parsed.MultiStatements)
+}
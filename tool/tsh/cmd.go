@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tsh
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/api/client"
+	"github.com/gravitational/teleport/lib/client/metrics"
+
+	"github.com/gravitational/trace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// viperContextKey is the context key NewRootCommand stores the root
+// command's Viper instance under, so subcommands can reuse it via
+// ProfileFromCommand instead of re-registering the same flags.
+type viperContextKey struct{}
+
+// metricsAddrFlag is the name of the opt-in flag that turns on the metrics
+// and health-check server for long-running tsh invocations.
+const metricsAddrFlag = "metrics-addr"
+
+// metricsServerContextKey is the context key NewRootCommand stores the
+// running metrics server under, once started, so it can be shut down again
+// after the invoked subcommand returns.
+type metricsServerContextKey struct{}
+
+// NewRootCommand builds the root "tsh" Cobra command with the profile
+// flags bound to Viper, the "tsh config" subcommand attached, and the
+// opt-in "--metrics-addr" metrics server wired to start before and stop
+// after whichever subcommand is invoked. Other tsh subcommands (ssh,
+// login, db, ...) are added to the returned command by their own packages
+// via AddCommand.
+func NewRootCommand(profileDir string) (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:           "tsh",
+		Short:         "tsh is a command-line tool for accessing Teleport clusters",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	v := NewViper(profileDir)
+	if err := BindProfileFlags(root, v); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	root.SetContext(context.WithValue(context.Background(), viperContextKey{}, v))
+	root.AddCommand(NewConfigCommand(v, profileDir))
+
+	root.PersistentFlags().String(metricsAddrFlag, "", "Address to serve Prometheus metrics and a health check on, e.g. 127.0.0.1:9090 (disabled unless set)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return startMetricsServer(cmd)
+	}
+	root.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		return stopMetricsServer(cmd)
+	}
+
+	return root, nil
+}
+
+// startMetricsServer starts the opt-in metrics server if --metrics-addr was
+// set on the root command, recording it on the root command's context so
+// stopMetricsServer can shut it down once the invoked subcommand returns.
+func startMetricsServer(cmd *cobra.Command) error {
+	root := cmd.Root()
+	addr, err := root.PersistentFlags().GetString(metricsAddrFlag)
+	if err != nil || addr == "" {
+		return nil
+	}
+	server, err := metrics.NewServer(metrics.Config{ListenAddr: addr})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	go server.ListenAndServe()
+	root.SetContext(context.WithValue(root.Context(), metricsServerContextKey{}, server))
+	return nil
+}
+
+// stopMetricsServer shuts down the metrics server started by
+// startMetricsServer, if --metrics-addr was set.
+func stopMetricsServer(cmd *cobra.Command) error {
+	server, ok := cmd.Root().Context().Value(metricsServerContextKey{}).(*metrics.Server)
+	if !ok {
+		return nil
+	}
+	return trace.Wrap(server.Shutdown(context.Background()))
+}
+
+// ProfileFromCommand resolves a client.Profile for the current invocation
+// by merging the root command's bound flags over the on-disk profile. It
+// reuses the Viper instance NewRootCommand already bound the flags to,
+// rather than registering the same flags a second time.
+func ProfileFromCommand(cmd *cobra.Command, profileDir string) (*client.Profile, error) {
+	root := cmd.Root()
+	v, ok := root.Context().Value(viperContextKey{}).(*viper.Viper)
+	if !ok {
+		return nil, trace.BadParameter("command was not built with tsh.NewRootCommand")
+	}
+	fileProfile, err := client.ProfileFromDir(profileDir, "")
+	if err != nil {
+		fileProfile = nil
+	}
+	profile, _ := ResolveProfile(v, root.PersistentFlags(), fileProfile)
+	profile.Dir = profileDir
+	return profile, nil
+}
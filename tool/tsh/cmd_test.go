@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tsh
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProfileFromCommandDoesNotReregisterFlags verifies that a subcommand
+// calling ProfileFromCommand doesn't panic by re-registering the profile
+// flags that NewRootCommand already bound on the root command.
+func TestProfileFromCommandDoesNotReregisterFlags(t *testing.T) {
+	root, err := NewRootCommand(t.TempDir())
+	require.NoError(t, err)
+
+	var resolvedUser string
+	sub := &cobra.Command{
+		Use: "whoami",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := ProfileFromCommand(cmd, t.TempDir())
+			if err != nil {
+				return err
+			}
+			resolvedUser = profile.Username
+			return nil
+		},
+	}
+	root.AddCommand(sub)
+	root.SetArgs([]string{"whoami", "--user", "alice"})
+
+	require.NotPanics(t, func() {
+		require.NoError(t, root.Execute())
+	})
+	require.Equal(t, "alice", resolvedUser)
+}
+
+// TestMetricsServerStartsAndStopsWithCommand verifies that setting
+// --metrics-addr starts the metrics server before the invoked subcommand
+// runs, and that NewRootCommand shuts it down again once it returns.
+func TestMetricsServerStartsAndStopsWithCommand(t *testing.T) {
+	const addr = "127.0.0.1:18372"
+
+	root, err := NewRootCommand(t.TempDir())
+	require.NoError(t, err)
+
+	var healthzDuringRun int
+	sub := &cobra.Command{
+		Use: "noop",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := http.Get("http://" + addr + "/healthz")
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			healthzDuringRun = resp.StatusCode
+			return nil
+		},
+	}
+	root.AddCommand(sub)
+	root.SetArgs([]string{"noop", "--metrics-addr", addr})
+
+	require.NoError(t, root.Execute())
+	require.Equal(t, http.StatusOK, healthzDuringRun)
+
+	// The server should have been shut down once the subcommand returned.
+	_, err = http.Get("http://" + addr + "/healthz")
+	require.Error(t, err)
+}
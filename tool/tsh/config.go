@@ -0,0 +1,249 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tsh provides the Cobra/Viper configuration layer for the tsh CLI.
+// Every field on client.Profile that tsh commands accept as a flag is
+// defined once here and bound to Viper so it can also be set via a
+// "TSH_*" environment variable or the "~/.tsh/config.yaml" config file,
+// with precedence flag > env > config file > profile file > default.
+package tsh
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gravitational/teleport/api/client"
+
+	"github.com/gravitational/trace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// envPrefix is the prefix Viper uses to map flags to environment
+// variables, e.g. the "user" flag binds to "TSH_USER".
+const envPrefix = "TSH"
+
+// configFileName is the name of the tsh config file within the profile
+// directory.
+const configFileName = "config.yaml"
+
+// profileFlag describes a single client.Profile field exposed as a
+// persistent Cobra flag.
+type profileFlag struct {
+	// name is the flag name, e.g. "web-proxy-addr".
+	name string
+	// usage is the flag's help text.
+	usage string
+	// isSlice is true for the two forwarded-ports fields, which take
+	// multiple values.
+	isSlice bool
+}
+
+// profileFlags enumerates every client.Profile field tsh commands can
+// override, in the same order they're declared on the struct.
+var profileFlags = []profileFlag{
+	{name: "web-proxy-addr", usage: "Address of the web proxy"},
+	{name: "ssh-proxy-addr", usage: "Address of the SSH proxy"},
+	{name: "kube-proxy-addr", usage: "Address of the Kubernetes proxy"},
+	{name: "user", usage: "Teleport username"},
+	{name: "auth", usage: "Authentication connector name"},
+	{name: "cluster", usage: "Teleport cluster name"},
+	{name: "forward-ports", usage: "Local port forwarding specs", isSlice: true},
+	{name: "dynamic-forward-ports", usage: "Dynamic (SOCKS5) port forwarding specs", isSlice: true},
+}
+
+// NewViper returns a Viper instance configured to read TSH_* environment
+// variables and "<profileDir>/config.yaml", ready to be bound to a root
+// command's flags with BindProfileFlags.
+func NewViper(profileDir string) *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	v.SetConfigName(strings.TrimSuffix(configFileName, ".yaml"))
+	v.SetConfigType("yaml")
+	v.AddConfigPath(profileDir)
+	// Config file is optional; a missing file isn't an error.
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Swallow parse errors here too; callers that care can call
+			// v.ReadInConfig() again and inspect the error themselves.
+			_ = err
+		}
+	}
+	return v
+}
+
+// BindProfileFlags registers a persistent Cobra flag for every
+// client.Profile field and binds each one to v.
+func BindProfileFlags(cmd *cobra.Command, v *viper.Viper) error {
+	for _, f := range profileFlags {
+		if f.isSlice {
+			cmd.PersistentFlags().StringSlice(f.name, nil, f.usage)
+		} else {
+			cmd.PersistentFlags().String(f.name, "", f.usage)
+		}
+		if err := v.BindPFlag(f.name, cmd.PersistentFlags().Lookup(f.name)); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// source identifies where a resolved configuration value came from.
+type source string
+
+const (
+	sourceFlag        source = "flag"
+	sourceEnv         source = "env"
+	sourceConfigFile  source = "config-file"
+	sourceProfileFile source = "profile-file"
+	sourceDefault     source = "default"
+)
+
+// ResolvedValue is a configuration value together with where it came from.
+type ResolvedValue struct {
+	Value  string
+	Source source
+}
+
+// ResolveProfile merges Viper-bound values (flag > env > config file) over
+// fields from an existing on-disk profile (profile-file), over the
+// zero-value default, and returns both the merged profile and, per field,
+// which layer won - used by "tsh config" to annotate sources. flags is the
+// FlagSet the profile flags were registered on (e.g. the root command's
+// persistent flags); it's consulted to tell an explicitly-passed flag
+// apart from a value Viper is only reporting because it's set in the
+// config file.
+func ResolveProfile(v *viper.Viper, flags *pflag.FlagSet, fileProfile *client.Profile) (*client.Profile, map[string]ResolvedValue) {
+	resolved := &client.Profile{}
+	sources := make(map[string]ResolvedValue, len(profileFlags))
+
+	resolve := func(name string, fileValue string) ResolvedValue {
+		if flags != nil {
+			if f := flags.Lookup(name); f != nil && f.Changed {
+				return ResolvedValue{Value: v.GetString(name), Source: sourceFlag}
+			}
+		}
+		if _, ok := os.LookupEnv(envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))); ok {
+			return ResolvedValue{Value: v.GetString(name), Source: sourceEnv}
+		}
+		if v.IsSet(name) {
+			// Neither an explicit flag nor an env var contributed this
+			// value, so IsSet being true here means it came from the
+			// config file.
+			return ResolvedValue{Value: v.GetString(name), Source: sourceConfigFile}
+		}
+		if fileValue != "" {
+			return ResolvedValue{Value: fileValue, Source: sourceProfileFile}
+		}
+		return ResolvedValue{Source: sourceDefault}
+	}
+
+	var webProxyAddr, sshProxyAddr, kubeProxyAddr, user, auth, cluster string
+	if fileProfile != nil {
+		webProxyAddr = fileProfile.WebProxyAddr
+		sshProxyAddr = fileProfile.SSHProxyAddr
+		kubeProxyAddr = fileProfile.KubeProxyAddr
+		user = fileProfile.Username
+		auth = fileProfile.AuthType
+		cluster = fileProfile.SiteName
+	}
+
+	for name, fileValue := range map[string]string{
+		"web-proxy-addr":  webProxyAddr,
+		"ssh-proxy-addr":  sshProxyAddr,
+		"kube-proxy-addr": kubeProxyAddr,
+		"user":            user,
+		"auth":            auth,
+		"cluster":         cluster,
+	} {
+		rv := resolve(name, fileValue)
+		sources[name] = rv
+		switch name {
+		case "web-proxy-addr":
+			resolved.WebProxyAddr = rv.Value
+		case "ssh-proxy-addr":
+			resolved.SSHProxyAddr = rv.Value
+		case "kube-proxy-addr":
+			resolved.KubeProxyAddr = rv.Value
+		case "user":
+			resolved.Username = rv.Value
+		case "auth":
+			resolved.AuthType = rv.Value
+		case "cluster":
+			resolved.SiteName = rv.Value
+		}
+	}
+
+	var forwardedPortsFile, dynamicForwardedPortsFile []string
+	if fileProfile != nil {
+		forwardedPortsFile = fileProfile.ForwardedPorts
+		dynamicForwardedPortsFile = fileProfile.DynamicForwardedPorts
+	}
+
+	resolveSlice := func(name string, fileValue []string) (ResolvedValue, []string) {
+		if flags != nil {
+			if f := flags.Lookup(name); f != nil && f.Changed {
+				val := v.GetStringSlice(name)
+				return ResolvedValue{Value: strings.Join(val, ","), Source: sourceFlag}, val
+			}
+		}
+		if _, ok := os.LookupEnv(envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))); ok {
+			val := v.GetStringSlice(name)
+			return ResolvedValue{Value: strings.Join(val, ","), Source: sourceEnv}, val
+		}
+		if v.IsSet(name) {
+			val := v.GetStringSlice(name)
+			return ResolvedValue{Value: strings.Join(val, ","), Source: sourceConfigFile}, val
+		}
+		if len(fileValue) > 0 {
+			return ResolvedValue{Value: strings.Join(fileValue, ","), Source: sourceProfileFile}, fileValue
+		}
+		return ResolvedValue{Source: sourceDefault}, nil
+	}
+
+	var fpValue, dfpValue []string
+	sources["forward-ports"], fpValue = resolveSlice("forward-ports", forwardedPortsFile)
+	resolved.ForwardedPorts = fpValue
+	sources["dynamic-forward-ports"], dfpValue = resolveSlice("dynamic-forward-ports", dynamicForwardedPortsFile)
+	resolved.DynamicForwardedPorts = dfpValue
+
+	return resolved, sources
+}
+
+// NewConfigCommand returns the "tsh config" subcommand, which prints the
+// fully resolved tsh configuration along with where each value came from.
+func NewConfigCommand(v *viper.Viper, profileDir string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Print the resolved tsh configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fileProfile, err := client.ProfileFromDir(profileDir, "")
+			if err != nil {
+				fileProfile = nil
+			}
+			_, sources := ResolveProfile(v, cmd.Root().PersistentFlags(), fileProfile)
+			for _, f := range profileFlags {
+				rv := sources[f.name]
+				fmt.Fprintf(cmd.OutOrStdout(), "%-24s %-40s (%s)\n", f.name, rv.Value, rv.Source)
+			}
+			return nil
+		},
+	}
+}
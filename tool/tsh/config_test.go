@@ -0,0 +1,78 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tsh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/teleport/api/client"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveProfileSourcePrecedence verifies that a value set both in the
+// config file and via an explicit flag is reported as coming from the
+// flag, matching the documented flag > env > config-file precedence.
+func TestResolveProfileSourcePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte("user: bob-from-config\n"), 0600))
+
+	cmd := &cobra.Command{Use: "root"}
+	v := NewViper(dir)
+	require.NoError(t, BindProfileFlags(cmd, v))
+	require.NoError(t, cmd.ParseFlags([]string{"--user", "alice-from-flag"}))
+
+	_, sources := ResolveProfile(v, cmd.PersistentFlags(), nil)
+	require.Equal(t, "alice-from-flag", sources["user"].Value)
+	require.Equal(t, sourceFlag, sources["user"].Source)
+}
+
+// TestResolveProfileSourceConfigFile verifies that a value present only in
+// the config file is reported with source config-file.
+func TestResolveProfileSourceConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte("user: bob-from-config\n"), 0600))
+
+	cmd := &cobra.Command{Use: "root"}
+	v := NewViper(dir)
+	require.NoError(t, BindProfileFlags(cmd, v))
+
+	_, sources := ResolveProfile(v, cmd.PersistentFlags(), nil)
+	require.Equal(t, "bob-from-config", sources["user"].Value)
+	require.Equal(t, sourceConfigFile, sources["user"].Source)
+}
+
+// TestResolveProfileForwardedPortsSource verifies that forward-ports and
+// dynamic-forward-ports get a source annotation even when neither a flag
+// nor the config file set them, matching the scalar fields' behavior.
+func TestResolveProfileForwardedPortsSource(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := &cobra.Command{Use: "root"}
+	v := NewViper(dir)
+	require.NoError(t, BindProfileFlags(cmd, v))
+
+	fileProfile := &client.Profile{ForwardedPorts: []string{"8080:localhost:80"}}
+	resolved, sources := ResolveProfile(v, cmd.PersistentFlags(), fileProfile)
+	require.Equal(t, []string{"8080:localhost:80"}, resolved.ForwardedPorts)
+	require.Equal(t, "8080:localhost:80", sources["forward-ports"].Value)
+	require.Equal(t, sourceProfileFile, sources["forward-ports"].Source)
+	require.Equal(t, sourceDefault, sources["dynamic-forward-ports"].Source)
+}